@@ -0,0 +1,245 @@
+package serverinterceptors
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var methodStreamTimeouts sync.Map
+
+// StreamTimeouts configures the durations enforced by StreamTimeoutInterceptor
+// for a single streaming method. A zero duration disables the corresponding
+// check.
+type StreamTimeouts struct {
+	// Stream bounds the overall lifetime of the stream.
+	Stream time.Duration
+	// Message bounds how long a single SendMsg/RecvMsg call may take.
+	Message time.Duration
+	// Idle bounds how long the stream may go without a SendMsg/RecvMsg call in
+	// either direction.
+	Idle time.Duration
+}
+
+// SetStreamTimeoutsForFullMethod sets the specified stream timeouts for the
+// given method.
+func SetStreamTimeoutsForFullMethod(fullMethod string, timeouts StreamTimeouts) {
+	methodStreamTimeouts.Store(fullMethod, timeouts)
+}
+
+// StreamTimeoutStrategy lets a streaming server register custom per-method
+// stream timeout policies, mirroring TimeoutStrategy for unary methods. It is
+// a separate interface from TimeoutStrategy because grpc.StreamServerInfo,
+// unlike grpc.UnaryServerInfo, carries no Server field to type-assert against
+// — the server instance is instead the srv argument grpc-go passes into the
+// stream interceptor itself, so implementers are detected from that.
+type StreamTimeoutStrategy interface {
+	// GetStreamTimeoutsByFullMethod returns the stream timeouts to apply for
+	// fullMethod, falling back to defaultTimeouts when no policy is registered.
+	GetStreamTimeoutsByFullMethod(fullMethod string, defaultTimeouts StreamTimeouts) StreamTimeouts
+}
+
+// StreamTimeoutInterceptor returns a func that enforces an overall deadline,
+// a per-message timeout and an idle timeout on incoming streaming requests,
+// mirroring UnaryTimeoutInterceptor for streams.
+//
+// A handler blocked in a real SendMsg/RecvMsg call is blocked against the
+// transport, not against wrapped's derived context, so canceling that
+// context alone can never unblock it. The interceptor therefore runs the
+// handler in its own goroutine and races it against ctx.Done, the same
+// pattern UnaryTimeoutInterceptor uses, so the call returns once any timeout
+// fires even if the handler itself never does. That handler goroutine is
+// then abandoned rather than killed — Go has no way to forcibly stop it —
+// so timeoutServerStream refuses any SendMsg/RecvMsg it makes afterwards
+// instead of letting it keep calling into the real, now-unowned
+// grpc.ServerStream concurrently with whatever happens next on the
+// connection.
+func StreamTimeoutInterceptor(defaults StreamTimeouts) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		timeouts := getStreamTimeouts(srv, info.FullMethod, defaults)
+
+		ctx := ss.Context()
+		var cancel context.CancelFunc
+		if timeouts.Stream > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeouts.Stream)
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
+		defer cancel()
+
+		wrapped := &timeoutServerStream{
+			ServerStream:   ss,
+			ctx:            ctx,
+			cancel:         cancel,
+			messageTimeout: timeouts.Message,
+			idleTimeout:    timeouts.Idle,
+		}
+		if timeouts.Idle > 0 {
+			wrapped.idleTimer = time.AfterFunc(timeouts.Idle, wrapped.onIdleTimeout)
+		}
+		defer wrapped.stop()
+
+		done := make(chan error, 1)
+		go func() {
+			done <- handler(srv, wrapped)
+		}()
+
+		select {
+		case err := <-done:
+			if wrapped.timedOut.Load() {
+				return status.Error(codes.DeadlineExceeded, wrapped.timeoutReason())
+			} else if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+			}
+			return err
+		case <-ctx.Done():
+			wrapped.abandon()
+			return status.Error(codes.DeadlineExceeded, wrapped.timeoutReason())
+		}
+	}
+}
+
+// getStreamTimeouts resolves the stream timeouts to apply for fullMethod.
+// Unlike the unary path, grpc.StreamServerInfo carries no Server field, so the
+// server instance is taken from srv, the value grpc-go passes into the stream
+// interceptor directly.
+func getStreamTimeouts(srv any, fullMethod string, defaults StreamTimeouts) StreamTimeouts {
+	if ts, ok := srv.(StreamTimeoutStrategy); ok {
+		return ts.GetStreamTimeoutsByFullMethod(fullMethod, defaults)
+	} else if v, ok := methodStreamTimeouts.Load(fullMethod); ok {
+		if t, ok := v.(StreamTimeouts); ok {
+			return t
+		}
+	}
+
+	return defaults
+}
+
+// timeoutServerStream wraps a grpc.ServerStream, resetting an idle timer on
+// every SendMsg/RecvMsg and enforcing a per-message timeout, canceling the
+// stream's context once either expires. Once abandon has been called (the
+// owning interceptor call gave up on the handler), it refuses any further
+// SendMsg/RecvMsg instead of forwarding to the real ServerStream, since the
+// abandoned handler goroutine may still be running concurrently with
+// whatever now owns the RPC.
+type timeoutServerStream struct {
+	grpc.ServerStream
+	ctx            context.Context
+	cancel         context.CancelFunc
+	messageTimeout time.Duration
+	idleTimeout    time.Duration
+	idleTimer      *time.Timer
+	timedOut       atomic.Bool
+	reason         atomic.Value // string, the reason the most recent timeout fired
+}
+
+func (s *timeoutServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *timeoutServerStream) SendMsg(m any) error {
+	return s.withMessageTimeout(func() error {
+		return s.ServerStream.SendMsg(m)
+	})
+}
+
+func (s *timeoutServerStream) RecvMsg(m any) error {
+	return s.withMessageTimeout(func() error {
+		return s.ServerStream.RecvMsg(m)
+	})
+}
+
+// withMessageTimeout races fn, the real SendMsg/RecvMsg call, against the
+// per-message timer. If fn wins, its result is returned as-is. If the timer
+// wins, fn's goroutine is abandoned: fn may be blocked on the transport and
+// never return (a genuinely stuck peer), so nothing waits on it. onMessageTimeout
+// cancels ctx the same way onIdleTimeout does, so the outer
+// StreamTimeoutInterceptor select abandons the whole call too instead of
+// only this one SendMsg/RecvMsg — without that, a handler that treats one
+// message error as non-fatal and keeps calling SendMsg/RecvMsg (refused
+// immediately by the timedOut check above) could otherwise run until the
+// stream's own Context().Done() is next observed, rather than failing the
+// RPC as soon as the message timeout fires.
+func (s *timeoutServerStream) withMessageTimeout(fn func() error) error {
+	if s.timedOut.Load() {
+		return status.Error(codes.DeadlineExceeded, s.timeoutReason())
+	}
+
+	if s.idleTimer != nil {
+		s.idleTimer.Reset(s.idleTimeout)
+	}
+
+	if s.messageTimeout <= 0 {
+		return fn()
+	}
+
+	timer := time.NewTimer(s.messageTimeout)
+	defer timer.Stop()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		s.onMessageTimeout()
+		return status.Error(codes.DeadlineExceeded, s.timeoutReason())
+	}
+}
+
+func (s *timeoutServerStream) onIdleTimeout() {
+	s.reason.Store("stream idle timeout exceeded")
+	s.timedOut.Store(true)
+	s.cancel()
+}
+
+// onMessageTimeout cancels ctx in addition to marking the stream timed out,
+// so that the outer StreamTimeoutInterceptor select — which is what actually
+// abandons the RPC — reacts to a message timeout exactly as it would to a
+// stream or idle timeout.
+func (s *timeoutServerStream) onMessageTimeout() {
+	s.reason.Store("stream message timeout exceeded")
+	s.timedOut.Store(true)
+	s.cancel()
+}
+
+// abandon marks the stream as timed out because the owning interceptor call
+// gave up waiting on the handler (the overall Stream deadline fired, or the
+// handler was still blocked in a real SendMsg/RecvMsg when an idle/message
+// timeout canceled its context). It does not itself cancel anything new, but
+// ensures any SendMsg/RecvMsg the abandoned handler goroutine makes from
+// here on is refused rather than forwarded to the real ServerStream.
+func (s *timeoutServerStream) abandon() {
+	if s.timedOut.CompareAndSwap(false, true) {
+		s.reason.Store("stream timeout exceeded")
+	}
+}
+
+// timeoutReason returns a fixed, accurate description of why the stream
+// timed out. ctx.Err() cannot be used for this: once onIdleTimeout or
+// onMessageTimeout cancels ctx, ctx.Err() reports context.Canceled rather
+// than context.DeadlineExceeded, which would contradict the
+// codes.DeadlineExceeded status the interceptor returns.
+func (s *timeoutServerStream) timeoutReason() string {
+	if reason, ok := s.reason.Load().(string); ok {
+		return reason
+	}
+
+	return "stream timeout exceeded"
+}
+
+func (s *timeoutServerStream) stop() {
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+}
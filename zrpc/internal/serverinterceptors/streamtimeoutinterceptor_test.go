@@ -0,0 +1,212 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	sendDelay time.Duration
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeServerStream) SendMsg(m any) error {
+	if s.sendDelay > 0 {
+		time.Sleep(s.sendDelay)
+	}
+	return nil
+}
+
+func (s *fakeServerStream) RecvMsg(m any) error {
+	return nil
+}
+
+func TestStreamTimeoutInterceptor_OK(t *testing.T) {
+	ss := &fakeServerStream{ctx: context.Background()}
+	interceptor := StreamTimeoutInterceptor(StreamTimeouts{Stream: time.Second})
+
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/foo"},
+		func(srv any, stream grpc.ServerStream) error {
+			return stream.SendMsg("msg")
+		})
+
+	assert.NoError(t, err)
+}
+
+func TestStreamTimeoutInterceptor_StreamTimeout(t *testing.T) {
+	ss := &fakeServerStream{ctx: context.Background()}
+	interceptor := StreamTimeoutInterceptor(StreamTimeouts{Stream: 10 * time.Millisecond})
+
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/foo"},
+		func(srv any, stream grpc.ServerStream) error {
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+// blockingServerStream models a real transport call that blocks until the
+// peer sends something, unlike fakeServerStream's RecvMsg/SendMsg, which
+// return immediately. A handler that calls RecvMsg directly (the common
+// `for { stream.RecvMsg(...) }` shape) rather than selecting on
+// stream.Context().Done() can only be unblocked by abandoning it, never by
+// canceling its context.
+type blockingServerStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	release chan struct{}
+}
+
+func (s *blockingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *blockingServerStream) RecvMsg(any) error {
+	<-s.release
+	return nil
+}
+
+func (s *blockingServerStream) SendMsg(any) error {
+	<-s.release
+	return nil
+}
+
+func TestStreamTimeoutInterceptor_StreamTimeout_HandlerBlockedInRecv(t *testing.T) {
+	ss := &blockingServerStream{ctx: context.Background(), release: make(chan struct{})}
+	defer close(ss.release)
+	interceptor := StreamTimeoutInterceptor(StreamTimeouts{Stream: 10 * time.Millisecond})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/foo"},
+			func(srv any, stream grpc.ServerStream) error {
+				return stream.RecvMsg(nil)
+			})
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	case <-time.After(time.Second):
+		t.Fatal("interceptor did not return once the stream timeout fired")
+	}
+}
+
+func TestStreamTimeoutInterceptor_IdleTimeout_HandlerBlockedInRecv(t *testing.T) {
+	ss := &blockingServerStream{ctx: context.Background(), release: make(chan struct{})}
+	defer close(ss.release)
+	interceptor := StreamTimeoutInterceptor(StreamTimeouts{Idle: 10 * time.Millisecond})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/foo"},
+			func(srv any, stream grpc.ServerStream) error {
+				return stream.RecvMsg(nil)
+			})
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	case <-time.After(time.Second):
+		t.Fatal("interceptor did not return once the idle timeout fired")
+	}
+}
+
+func TestStreamTimeoutInterceptor_MessageTimeout(t *testing.T) {
+	ss := &fakeServerStream{ctx: context.Background(), sendDelay: 50 * time.Millisecond}
+	interceptor := StreamTimeoutInterceptor(StreamTimeouts{Message: 10 * time.Millisecond})
+
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/foo"},
+		func(srv any, stream grpc.ServerStream) error {
+			return stream.SendMsg("msg")
+		})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestStreamTimeoutInterceptor_MessageTimeout_HandlerBlockedInSend(t *testing.T) {
+	ss := &blockingServerStream{ctx: context.Background(), release: make(chan struct{})}
+	defer close(ss.release)
+	interceptor := StreamTimeoutInterceptor(StreamTimeouts{Message: 10 * time.Millisecond})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/foo"},
+			func(srv any, stream grpc.ServerStream) error {
+				return stream.SendMsg("msg")
+			})
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	case <-time.After(time.Second):
+		t.Fatal("interceptor did not return once the message timeout fired, even though " +
+			"the real SendMsg call it abandoned never returns")
+	}
+}
+
+func TestStreamTimeoutInterceptor_IdleTimeout(t *testing.T) {
+	ss := &fakeServerStream{ctx: context.Background()}
+	interceptor := StreamTimeoutInterceptor(StreamTimeouts{Idle: 10 * time.Millisecond})
+
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/foo"},
+		func(srv any, stream grpc.ServerStream) error {
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	// The status message must describe a timeout, not "context canceled":
+	// onIdleTimeout unblocks the handler by canceling wrapped's context, which
+	// makes ctx.Err() report context.Canceled even though the status code is
+	// DeadlineExceeded.
+	assert.Contains(t, status.Convert(err).Message(), "timeout")
+}
+
+func TestTimeoutServerStream_RefusesSendRecvAfterAbandon(t *testing.T) {
+	ss := &fakeServerStream{ctx: context.Background()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wrapped := &timeoutServerStream{ServerStream: ss, ctx: ctx, cancel: cancel}
+	wrapped.abandon()
+
+	err := wrapped.SendMsg("msg")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	err = wrapped.RecvMsg(new(any))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+type fakeStreamStrategyServer struct {
+	timeouts StreamTimeouts
+}
+
+func (s *fakeStreamStrategyServer) GetStreamTimeoutsByFullMethod(string, StreamTimeouts) StreamTimeouts {
+	return s.timeouts
+}
+
+func TestGetStreamTimeouts_UsesStrategyFromSrv(t *testing.T) {
+	srv := &fakeStreamStrategyServer{timeouts: StreamTimeouts{Stream: 42 * time.Second}}
+
+	got := getStreamTimeouts(srv, "/foo", StreamTimeouts{Stream: time.Second})
+	assert.Equal(t, 42*time.Second, got.Stream)
+}
+
+func TestGetStreamTimeouts_FallsBackToDefaults(t *testing.T) {
+	got := getStreamTimeouts(nil, "/foo", StreamTimeouts{Stream: time.Second})
+	assert.Equal(t, time.Second, got.Stream)
+}
@@ -9,12 +9,227 @@ import (
 	"sync"
 	"time"
 
+	"github.com/zeromicro/go-zero/core/metric"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-var methodTimeout sync.Map
+var (
+	methodTimeout sync.Map
+
+	handlerLeakMetric = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "rpc",
+		Subsystem: "server",
+		Name:      "handler_leak_total",
+		Help:      "rpc server handler goroutines that outlived their request's deadline.",
+		Labels:    []string{"method"},
+	})
+
+	admissionMetric = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "rpc",
+		Subsystem: "server",
+		Name:      "admission_total",
+		Help:      "rpc server admission control decisions, by method and result.",
+		Labels:    []string{"method", "result"},
+	})
+)
+
+// AdmissionPolicy decides whether a request with a given remaining deadline
+// budget should be allowed to start, so the server can shed requests that
+// are unlikely to complete before the client gives up rather than starting
+// doomed work.
+type AdmissionPolicy interface {
+	// Admit reports whether a request for fullMethod with remaining budget
+	// left on its deadline should be dispatched to its handler.
+	Admit(fullMethod string, remaining time.Duration) bool
+	// Observe records the actual handling latency for fullMethod, letting
+	// adaptive policies update their estimate of typical latency.
+	Observe(fullMethod string, latency time.Duration)
+}
+
+// StaticAdmissionPolicy admits a request only if its remaining budget meets
+// a fixed, per-method minimum.
+type StaticAdmissionPolicy struct {
+	minBudget map[string]time.Duration
+}
+
+// NewStaticAdmissionPolicy returns an AdmissionPolicy keyed by full method
+// name. Methods absent from minBudget are always admitted.
+func NewStaticAdmissionPolicy(minBudget map[string]time.Duration) *StaticAdmissionPolicy {
+	return &StaticAdmissionPolicy{minBudget: minBudget}
+}
+
+// Admit implements AdmissionPolicy.
+func (p *StaticAdmissionPolicy) Admit(fullMethod string, remaining time.Duration) bool {
+	min, ok := p.minBudget[fullMethod]
+	if !ok {
+		return true
+	}
+
+	return remaining >= min
+}
+
+// Observe implements AdmissionPolicy. StaticAdmissionPolicy ignores observed
+// latencies since its thresholds are fixed.
+func (p *StaticAdmissionPolicy) Observe(string, time.Duration) {
+}
+
+// AdaptiveAdmissionPolicy admits a request only if its remaining budget is at
+// least k times the EWMA of observed handler latency for that method.
+// Methods with no observed latency yet are always admitted.
+type AdaptiveAdmissionPolicy struct {
+	k     float64
+	decay float64
+	stats sync.Map // fullMethod -> *ewmaLatency
+}
+
+type ewmaLatency struct {
+	lock    sync.Mutex
+	average time.Duration
+}
+
+// NewAdaptiveAdmissionPolicy returns an AdmissionPolicy that refuses requests
+// whose remaining budget is less than k times the EWMA of that method's
+// observed handler latency.
+func NewAdaptiveAdmissionPolicy(k float64) *AdaptiveAdmissionPolicy {
+	return &AdaptiveAdmissionPolicy{
+		k:     k,
+		decay: 0.2,
+	}
+}
+
+// Admit implements AdmissionPolicy.
+func (p *AdaptiveAdmissionPolicy) Admit(fullMethod string, remaining time.Duration) bool {
+	v, ok := p.stats.Load(fullMethod)
+	if !ok {
+		return true
+	}
+
+	l := v.(*ewmaLatency)
+	l.lock.Lock()
+	avg := l.average
+	l.lock.Unlock()
+	if avg <= 0 {
+		return true
+	}
+
+	return remaining >= time.Duration(p.k*float64(avg))
+}
+
+// Observe implements AdmissionPolicy.
+func (p *AdaptiveAdmissionPolicy) Observe(fullMethod string, latency time.Duration) {
+	v, _ := p.stats.LoadOrStore(fullMethod, new(ewmaLatency))
+	l := v.(*ewmaLatency)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.average == 0 {
+		l.average = latency
+		return
+	}
+	l.average = time.Duration(p.decay*float64(latency) + (1-p.decay)*float64(l.average))
+}
+
+// HandlerLeakHook is called when a handler goroutine fails to observe a
+// canceled context within the configured grace period, so operators can wire
+// their own alerting in addition to the built-in metric.
+type HandlerLeakHook func(fullMethod string, elapsed time.Duration)
+
+// TimeoutMode controls how the effective timeout for an incoming request is
+// derived from the configured method timeout and the incoming context's own
+// deadline.
+type TimeoutMode int
+
+const (
+	// TimeoutModeFixed always uses the configured method timeout as the
+	// effective timeout, without looking at any deadline the client
+	// propagated. This is the historical behavior. The handler's context is
+	// still derived from the incoming one, so an earlier client deadline keeps
+	// applying to it.
+	TimeoutModeFixed TimeoutMode = iota
+	// TimeoutModeRespectClientDeadline derives the effective timeout as
+	// min(clientDeadline, methodTimeout), so that the computed timeout never
+	// exceeds what is left of a client-propagated deadline (e.g. from
+	// grpc-timeout metadata).
+	TimeoutModeRespectClientDeadline
+	// TimeoutModeShrinkingBudget behaves like TimeoutModeRespectClientDeadline,
+	// but additionally reserves a fraction of the remaining budget for this
+	// service's own downstream calls, so the propagated deadline shrinks as it
+	// passes through the call graph.
+	TimeoutModeShrinkingBudget
+)
+
+// TimeoutOption customizes how UnaryTimeoutInterceptor computes the effective
+// timeout for an incoming request.
+type TimeoutOption func(*timeoutOptions)
+
+type timeoutOptions struct {
+	mode          TimeoutMode
+	ceiling       time.Duration
+	reserveRatio  float64
+	gracePeriod   time.Duration
+	onHandlerLeak HandlerLeakHook
+	admission     AdmissionPolicy
+}
+
+// WithTimeoutMode sets the strategy used to derive the effective timeout.
+// The default is TimeoutModeFixed, preserving existing behavior.
+func WithTimeoutMode(mode TimeoutMode) TimeoutOption {
+	return func(o *timeoutOptions) {
+		o.mode = mode
+	}
+}
+
+// WithTimeoutCeiling caps the effective timeout at ceiling regardless of what
+// the client requested, so a server can still bound how long a handler may
+// run even when honoring client-propagated deadlines.
+func WithTimeoutCeiling(ceiling time.Duration) TimeoutOption {
+	return func(o *timeoutOptions) {
+		o.ceiling = ceiling
+	}
+}
+
+// WithBudgetReserveRatio sets the fraction of the remaining budget, in
+// (0, 1), that is reserved for this service's downstream calls under
+// TimeoutModeShrinkingBudget. It has no effect in other modes.
+func WithBudgetReserveRatio(ratio float64) TimeoutOption {
+	return func(o *timeoutOptions) {
+		o.reserveRatio = ratio
+	}
+}
+
+// WithGracefulAbort enables "graceful abort" mode: once the context deadline
+// fires, the interceptor waits up to gracePeriod for the still-running
+// handler goroutine to observe the canceled context and return before giving
+// up. This lets a well-behaved handler's real response/error win a close
+// race, instead of always discarding it in favor of a bare DeadlineExceeded.
+// If the handler still hasn't returned once gracePeriod elapses, the
+// goroutine is abandoned (it keeps holding its lock and referencing req/resp)
+// and a leaked-goroutine metric is recorded for the method.
+func WithGracefulAbort(gracePeriod time.Duration) TimeoutOption {
+	return func(o *timeoutOptions) {
+		o.gracePeriod = gracePeriod
+	}
+}
+
+// WithOnHandlerLeak registers a hook invoked whenever a handler goroutine
+// fails to return within the grace period configured by WithGracefulAbort.
+func WithOnHandlerLeak(hook HandlerLeakHook) TimeoutOption {
+	return func(o *timeoutOptions) {
+		o.onHandlerLeak = hook
+	}
+}
+
+// WithAdmissionPolicy installs an AdmissionPolicy that is consulted before a
+// request is dispatched to its handler. A request whose remaining deadline
+// budget the policy rejects is failed immediately with codes.DeadlineExceeded
+// instead of starting handler work that is unlikely to finish in time.
+func WithAdmissionPolicy(policy AdmissionPolicy) TimeoutOption {
+	return func(o *timeoutOptions) {
+		o.admission = policy
+	}
+}
 
 // SetTimeoutForFullMethod set the specified timeout for given method.
 func SetTimeoutForFullMethod(fullMethod string, timeout time.Duration) {
@@ -22,13 +237,30 @@ func SetTimeoutForFullMethod(fullMethod string, timeout time.Duration) {
 }
 
 // UnaryTimeoutInterceptor returns a func that sets timeout to incoming unary requests.
-func UnaryTimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+func UnaryTimeoutInterceptor(timeout time.Duration, opts ...TimeoutOption) grpc.UnaryServerInterceptor {
+	options := timeoutOptions{mode: TimeoutModeFixed}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler) (any, error) {
-		timeout = getTimeoutByUnaryServerInfo(info, timeout)
-		ctx, cancel := context.WithTimeout(ctx, timeout)
+		resolvedTimeout := getTimeoutByUnaryServerInfo(info, timeout)
+		t := effectiveTimeout(ctx, resolvedTimeout, options)
+
+		if options.admission != nil {
+			if !options.admission.Admit(info.FullMethod, remainingBudget(ctx, resolvedTimeout)) {
+				admissionMetric.Inc(info.FullMethod, "shed")
+				return nil, status.Error(codes.DeadlineExceeded,
+					"insufficient remaining budget to admit request")
+			}
+			admissionMetric.Inc(info.FullMethod, "admitted")
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, t)
 		defer cancel()
 
+		start := time.Now()
 		var resp any
 		var err error
 		var lock sync.Mutex
@@ -55,8 +287,42 @@ func UnaryTimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor
 		case <-done:
 			lock.Lock()
 			defer lock.Unlock()
+			if options.admission != nil {
+				options.admission.Observe(info.FullMethod, time.Since(start))
+			}
 			return resp, err
 		case <-ctx.Done():
+			if options.gracePeriod > 0 {
+				graceTimer := time.NewTimer(options.gracePeriod)
+				defer graceTimer.Stop()
+
+				select {
+				case p := <-panicChan:
+					panic(p)
+				case <-done:
+					lock.Lock()
+					defer lock.Unlock()
+					if options.admission != nil {
+						options.admission.Observe(info.FullMethod, time.Since(start))
+					}
+					return resp, err
+				case <-graceTimer.C:
+					handlerLeakMetric.Inc(info.FullMethod)
+					if options.onHandlerLeak != nil {
+						options.onHandlerLeak(info.FullMethod, options.gracePeriod)
+					}
+				}
+			}
+
+			if options.admission != nil {
+				// The handler neither returned in time nor, if graceful abort
+				// was configured, within the grace period. Feed this latency
+				// into the policy too, otherwise an EWMA only ever sees fast
+				// completions and keeps admitting requests for a method that
+				// routinely blows its deadline.
+				options.admission.Observe(info.FullMethod, time.Since(start))
+			}
+
 			err := ctx.Err()
 			if errors.Is(err, context.Canceled) {
 				err = status.Error(codes.Canceled, err.Error())
@@ -68,6 +334,45 @@ func UnaryTimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor
 	}
 }
 
+// effectiveTimeout derives the timeout to actually apply to ctx, honoring the
+// incoming context's own deadline according to mode.
+func effectiveTimeout(ctx context.Context, methodTimeout time.Duration, options timeoutOptions) time.Duration {
+	result := methodTimeout
+
+	switch options.mode {
+	case TimeoutModeRespectClientDeadline, TimeoutModeShrinkingBudget:
+		if dl, ok := ctx.Deadline(); ok {
+			if remaining := time.Until(dl); remaining < result {
+				result = remaining
+			}
+		}
+		if options.mode == TimeoutModeShrinkingBudget && options.reserveRatio > 0 {
+			result = time.Duration(float64(result) * (1 - options.reserveRatio))
+		}
+	}
+
+	if options.ceiling > 0 && result > options.ceiling {
+		result = options.ceiling
+	}
+
+	return result
+}
+
+// remainingBudget reports the actual time left before the client's own
+// deadline (if any) expires, capped at methodTimeout. Unlike effectiveTimeout,
+// it ignores TimeoutMode, the ceiling and the shrinking-budget reserve, since
+// admission control cares about the real budget the caller still has, not the
+// timeout this server intends to apply to the handler.
+func remainingBudget(ctx context.Context, methodTimeout time.Duration) time.Duration {
+	if dl, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(dl); remaining < methodTimeout {
+			return remaining
+		}
+	}
+
+	return methodTimeout
+}
+
 func getTimeoutByUnaryServerInfo(info *grpc.UnaryServerInfo, defaultTimeout time.Duration) time.Duration {
 	if ts, ok := info.Server.(TimeoutStrategy); ok {
 		return ts.GetTimeoutByFullMethod(info.FullMethod, defaultTimeout)
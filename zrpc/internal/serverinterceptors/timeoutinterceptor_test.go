@@ -0,0 +1,223 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEffectiveTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           TimeoutMode
+		methodTimeout  time.Duration
+		clientDeadline time.Duration // 0 means no deadline on ctx
+		ceiling        time.Duration
+		reserveRatio   float64
+		want           time.Duration
+	}{
+		{
+			name:           "fixed mode ignores shorter client deadline",
+			mode:           TimeoutModeFixed,
+			methodTimeout:  time.Second,
+			clientDeadline: 100 * time.Millisecond,
+			want:           time.Second,
+		},
+		{
+			name:           "respect client deadline picks the smaller value",
+			mode:           TimeoutModeRespectClientDeadline,
+			methodTimeout:  time.Second,
+			clientDeadline: 100 * time.Millisecond,
+			want:           100 * time.Millisecond,
+		},
+		{
+			name:          "respect client deadline keeps method timeout when no deadline set",
+			mode:          TimeoutModeRespectClientDeadline,
+			methodTimeout: time.Second,
+			want:          time.Second,
+		},
+		{
+			name:           "shrinking budget reserves a fraction of the remaining budget",
+			mode:           TimeoutModeShrinkingBudget,
+			methodTimeout:  time.Second,
+			clientDeadline: time.Second,
+			reserveRatio:   0.5,
+			want:           500 * time.Millisecond,
+		},
+		{
+			name:          "ceiling caps the result regardless of mode",
+			mode:          TimeoutModeFixed,
+			methodTimeout: time.Second,
+			ceiling:       200 * time.Millisecond,
+			want:          200 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.clientDeadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.clientDeadline)
+				defer cancel()
+			}
+
+			options := timeoutOptions{
+				mode:         test.mode,
+				ceiling:      test.ceiling,
+				reserveRatio: test.reserveRatio,
+			}
+			got := effectiveTimeout(ctx, test.methodTimeout, options)
+			assert.InDelta(t, test.want, got, float64(10*time.Millisecond))
+		})
+	}
+}
+
+func TestRemainingBudget(t *testing.T) {
+	methodTimeout := time.Second
+
+	ctx := context.Background()
+	assert.Equal(t, methodTimeout, remainingBudget(ctx, methodTimeout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	got := remainingBudget(ctx, methodTimeout)
+	assert.True(t, got <= 50*time.Millisecond)
+}
+
+func TestUnaryTimeoutInterceptor_OK(t *testing.T) {
+	interceptor := UnaryTimeoutInterceptor(time.Second)
+	resp, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/foo"},
+		func(ctx context.Context, req any) (any, error) {
+			return "resp", nil
+		})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "resp", resp)
+}
+
+func TestUnaryTimeoutInterceptor_DeadlineExceeded(t *testing.T) {
+	interceptor := UnaryTimeoutInterceptor(time.Millisecond)
+	_, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/foo"},
+		func(ctx context.Context, req any) (any, error) {
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			return "resp", nil
+		})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestUnaryTimeoutInterceptor_GracefulAbortHandlerWins(t *testing.T) {
+	interceptor := UnaryTimeoutInterceptor(10*time.Millisecond, WithGracefulAbort(100*time.Millisecond))
+	resp, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/foo"},
+		func(ctx context.Context, req any) (any, error) {
+			<-ctx.Done()
+			return "late but real resp", nil
+		})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "late but real resp", resp)
+}
+
+func TestUnaryTimeoutInterceptor_GracefulAbortHandlerLeaks(t *testing.T) {
+	var leakedMethod string
+	var leakedElapsed time.Duration
+	interceptor := UnaryTimeoutInterceptor(10*time.Millisecond,
+		WithGracefulAbort(20*time.Millisecond),
+		WithOnHandlerLeak(func(fullMethod string, elapsed time.Duration) {
+			leakedMethod = fullMethod
+			leakedElapsed = elapsed
+		}))
+
+	_, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/foo"},
+		func(ctx context.Context, req any) (any, error) {
+			<-ctx.Done()
+			time.Sleep(time.Second)
+			return "resp", nil
+		})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, "/foo", leakedMethod)
+	assert.Equal(t, 20*time.Millisecond, leakedElapsed)
+}
+
+func TestStaticAdmissionPolicy(t *testing.T) {
+	policy := NewStaticAdmissionPolicy(map[string]time.Duration{"/foo": 100 * time.Millisecond})
+
+	assert.True(t, policy.Admit("/foo", 200*time.Millisecond))
+	assert.False(t, policy.Admit("/foo", 50*time.Millisecond))
+	assert.True(t, policy.Admit("/bar", time.Nanosecond))
+}
+
+func TestAdaptiveAdmissionPolicy(t *testing.T) {
+	policy := NewAdaptiveAdmissionPolicy(2)
+
+	// no observations yet, always admit.
+	assert.True(t, policy.Admit("/foo", time.Millisecond))
+
+	policy.Observe("/foo", 100*time.Millisecond)
+	assert.True(t, policy.Admit("/foo", 300*time.Millisecond))
+	assert.False(t, policy.Admit("/foo", 50*time.Millisecond))
+}
+
+type spyAdmissionPolicy struct {
+	admit    bool
+	observed []time.Duration
+}
+
+func (p *spyAdmissionPolicy) Admit(string, time.Duration) bool {
+	return p.admit
+}
+
+func (p *spyAdmissionPolicy) Observe(_ string, latency time.Duration) {
+	p.observed = append(p.observed, latency)
+}
+
+func TestUnaryTimeoutInterceptor_AdmissionControlSheds(t *testing.T) {
+	called := false
+	policy := NewStaticAdmissionPolicy(map[string]time.Duration{"/foo": time.Hour})
+	interceptor := UnaryTimeoutInterceptor(time.Second, WithAdmissionPolicy(policy))
+
+	_, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/foo"},
+		func(ctx context.Context, req any) (any, error) {
+			called = true
+			return "resp", nil
+		})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.False(t, called)
+}
+
+func TestUnaryTimeoutInterceptor_AdmissionObservesSuccess(t *testing.T) {
+	spy := &spyAdmissionPolicy{admit: true}
+	interceptor := UnaryTimeoutInterceptor(time.Second, WithAdmissionPolicy(spy))
+
+	_, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/foo"},
+		func(ctx context.Context, req any) (any, error) {
+			return "resp", nil
+		})
+
+	assert.NoError(t, err)
+	assert.Len(t, spy.observed, 1)
+}
+
+func TestUnaryTimeoutInterceptor_AdmissionObservesTimeout(t *testing.T) {
+	spy := &spyAdmissionPolicy{admit: true}
+	interceptor := UnaryTimeoutInterceptor(10*time.Millisecond, WithAdmissionPolicy(spy))
+
+	_, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/foo"},
+		func(ctx context.Context, req any) (any, error) {
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			return "resp", nil
+		})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Len(t, spy.observed, 1)
+}